@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runCheckCommand performs a single scan and reports the result, for use as
+// a Kubernetes Job/CronJob or CI gate instead of the long-running daemon.
+// It returns the process exit code: 0 if every certificate is fine, 1 if
+// any is within the warn window, 2 if any is within critical or expired.
+func runCheckCommand(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	output := fs.String("output", "table", "Output format: table|json|yaml")
+	namespace := fs.String("namespace", "", "Restrict the scan to a single namespace")
+	minDays := fs.Int("min-days", 0, "Override the warn threshold (days left) for this scan")
+	fs.Parse(args)
+
+	var appConfig ApplicationConfig
+	if err := envconfig.Process("", &appConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing config: %v\n", err)
+		return 2
+	}
+
+	if *minDays > 0 {
+		appConfig.WarnDaysLeft = *minDays
+	}
+
+	kubeClient, err := newKubernetesClient(&appConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to kubernetes: %v\n", err)
+		return 2
+	}
+	appConfig.KubeClient = kubeClient
+
+	certList, warnings, errs := scanCertificates(&appConfig, *namespace)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", e)
+	}
+
+	switch *output {
+	case "json":
+		j, _ := json.MarshalIndent(certList, "", "  ")
+		fmt.Println(string(j))
+	case "yaml":
+		y, _ := yaml.Marshal(certList)
+		fmt.Print(string(y))
+	default:
+		printCertificateTable(certList, &appConfig)
+	}
+
+	return checkExitCode(certList, &appConfig)
+}
+
+// scanCertificates performs a single, non-cached list of every
+// kubernetes.io/tls secret (optionally scoped to one namespace) and
+// evaluates it against appConfig's thresholds.
+func scanCertificates(appConfig *ApplicationConfig, namespace string) (certList []*CertificateInfo, warnings, errors []string) {
+	ctx := context.Background()
+
+	var namespaces []string
+	if namespace != "" {
+		namespaces = []string{namespace}
+	} else {
+		nsList, err := appConfig.KubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			errors = append(errors, err.Error())
+			return nil, warnings, errors
+		}
+
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	for _, ns := range namespaces {
+		secrets, err := appConfig.KubeClient.CoreV1().Secrets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+
+		for _, secret := range secrets.Items {
+			if secret.Type != "kubernetes.io/tls" {
+				continue
+			}
+
+			if originNS, ok := secret.Labels["kubed.appscode.com/origin.namespace"]; ok && originNS != ns {
+				continue
+			}
+
+			chain, caCerts, err := getx509Data(secret)
+			if err != nil {
+				errors = append(errors, err.Error())
+				continue
+			}
+
+			var certs []*CertificateInfo
+			certs, warnings, errors = evaluateCertificateChain(ns, secret.Name, chain, caCerts, appConfig, warnings, errors)
+			certList = append(certList, certs...)
+		}
+	}
+
+	return certList, warnings, errors
+}
+
+// thresholdsForKind returns the crit/warn days-left thresholds that apply to
+// a CertificateInfo, the same split evaluateCertificateChain uses: leaf
+// certificates are judged against CritDaysLeft/WarnDaysLeft, intermediates
+// and CA certs against the (usually much longer) CA thresholds.
+func thresholdsForKind(kind CertificateKind, appConfig *ApplicationConfig) (critDaysLeft, warnDaysLeft int) {
+	if kind == CertificateKindLeaf {
+		return appConfig.CritDaysLeft, appConfig.WarnDaysLeft
+	}
+
+	return appConfig.CACritDaysLeft, appConfig.CAWarnDaysLeft
+}
+
+// checkExitCode derives the `check` subcommand's exit code from the worst
+// state found across the scanned certificates, judging each entry against
+// the thresholds for its own Kind so a CA/intermediate cert isn't graded
+// against the (much tighter) leaf thresholds.
+func checkExitCode(certList []*CertificateInfo, appConfig *ApplicationConfig) int {
+	exitCode := 0
+
+	for _, info := range certList {
+		critDaysLeft, warnDaysLeft := thresholdsForKind(info.Kind, appConfig)
+
+		switch certificateState(info, critDaysLeft, warnDaysLeft) {
+		case "critical", "expired":
+			return 2
+		case "warn":
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+func printCertificateTable(certList []*CertificateInfo, appConfig *ApplicationConfig) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tSECRET\tKIND\tCOMMON NAME\tDAYS LEFT\tNOT AFTER\tSTATUS")
+
+	for _, info := range certList {
+		commonName := ""
+		if len(info.CommonNames) > 0 {
+			commonName = info.CommonNames[0]
+		}
+
+		critDaysLeft, warnDaysLeft := thresholdsForKind(info.Kind, appConfig)
+		status := certificateState(info, critDaysLeft, warnDaysLeft)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", info.Namespace, info.SecretName, info.Kind, commonName, info.DaysLeft, info.NotAfter, status)
+	}
+
+	w.Flush()
+}
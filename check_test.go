@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func certInfo(kind CertificateKind, daysLeft int) *CertificateInfo {
+	return &CertificateInfo{
+		SecretName: "example-tls",
+		Namespace:  "default",
+		Source:     SourceSecret,
+		Kind:       kind,
+		DaysLeft:   daysLeft,
+	}
+}
+
+func TestCheckExitCode(t *testing.T) {
+	appConfig := &ApplicationConfig{
+		CritDaysLeft:   3,
+		WarnDaysLeft:   30,
+		CACritDaysLeft: 30,
+		CAWarnDaysLeft: 365,
+	}
+
+	cases := []struct {
+		name     string
+		certList []*CertificateInfo
+		want     int
+	}{
+		{"all healthy", []*CertificateInfo{certInfo(CertificateKindLeaf, 60), certInfo(CertificateKindCA, 400)}, 0},
+		{"leaf warn", []*CertificateInfo{certInfo(CertificateKindLeaf, 10)}, 1},
+		{"leaf critical", []*CertificateInfo{certInfo(CertificateKindLeaf, 1)}, 2},
+		// A CA cert with 40 days left sits inside the CA warn window
+		// (< 365) but well outside the leaf thresholds; it must not be
+		// judged as "healthy" just because 40 days would clear a leaf cert.
+		{"ca warn", []*CertificateInfo{certInfo(CertificateKindCA, 40)}, 1},
+		// A CA cert with 10 days left is inside the CA critical window
+		// (<= 30) and must escalate to exit code 2, not the 1 a
+		// leaf-threshold evaluation would (wrongly) stop at.
+		{"ca critical", []*CertificateInfo{certInfo(CertificateKindCA, 10)}, 2},
+		{"intermediate expired", []*CertificateInfo{certInfo(CertificateKindIntermediate, 0)}, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := checkExitCode(tc.certList, appConfig); got != tc.want {
+				t.Errorf("checkExitCode() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
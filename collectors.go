@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/olesku/k8s-certmon/sources"
+	"k8s.io/client-go/dynamic"
+)
+
+// buildExtraCollectors constructs the optional sources.Collector list for
+// every additional certificate source the operator has enabled, one
+// collector per watched namespace so a namespace-scoped Role/RoleBinding
+// deployment (WATCH_NAMESPACES set) doesn't have its extra sources fall
+// back to listing cluster-wide and failing on RBAC.
+func buildExtraCollectors(appConfig *ApplicationConfig) []sources.Collector {
+	var collectors []sources.Collector
+	namespaces := splitWatchNamespaces(appConfig.WatchNamespaces)
+
+	var dynClient dynamic.Interface
+	if appConfig.EnableCertManagerSource {
+		restConfig, err := newRestConfig(appConfig)
+		if err != nil {
+			log.Printf("Error: failed to build config for cert-manager source: %s\n", err.Error())
+		} else if client, err := dynamic.NewForConfig(restConfig); err != nil {
+			log.Printf("Error: failed to build dynamic client for cert-manager source: %s\n", err.Error())
+		} else {
+			dynClient = client
+		}
+	}
+
+	for _, ns := range namespaces {
+		if appConfig.EnableCertManagerSource && dynClient != nil {
+			collectors = append(collectors, sources.NewCertManagerCollector(dynClient, appConfig.KubeClient, ns))
+		}
+
+		if appConfig.EnableIngressSource {
+			collectors = append(collectors, sources.NewIngressCollector(appConfig.KubeClient, ns))
+		}
+
+		if appConfig.EnableProbeSource {
+			collectors = append(collectors, sources.NewProbeCollector(appConfig.KubeClient, ns))
+		}
+	}
+
+	return collectors
+}
+
+// collectExtraCertificates runs every extra collector and appends its
+// results onto certList, keyed by CertificateInfo.Source for filtering.
+func collectExtraCertificates(ctx context.Context, collectors []sources.Collector, certList []*CertificateInfo, errors []string) ([]*CertificateInfo, []string) {
+	for _, collector := range collectors {
+		collected, err := collector.Collect(ctx)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+
+		for _, c := range collected {
+			certList = append(certList, fromSourceCertificate(c))
+		}
+	}
+
+	return certList, errors
+}
+
+func fromSourceCertificate(c sources.CertificateInfo) *CertificateInfo {
+	return &CertificateInfo{
+		SecretName:  c.SecretName,
+		Namespace:   c.Namespace,
+		Source:      c.Source,
+		Kind:        CertificateKind(c.Kind),
+		Issuer:      c.Issuer,
+		CommonNames: c.CommonNames,
+		NotBefore:   c.NotBefore,
+		NotAfter:    c.NotAfter,
+		DNSNames:    c.DNSNames,
+		DaysLeft:    c.DaysLeft,
+		IsValid:     c.IsValid,
+		Extra:       c.Extra,
+	}
+}
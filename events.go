@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	ReasonCertificateExpiringSoon = "CertificateExpiringSoon"
+	ReasonCertificateExpired      = "CertificateExpired"
+)
+
+// CertificateEventEmitter posts v1.Event objects against the Secret that
+// owns an expiring certificate, de-duplicated per (secret UID, threshold)
+// so repeated scans don't spam the same warning.
+type CertificateEventEmitter struct {
+	recorder record.EventRecorder
+
+	mu      sync.Mutex
+	emitted map[string]bool
+}
+
+// NewCertificateEventEmitter wires up an EventRecorder that posts events
+// under the given reporting component name.
+//
+// RBAC: the EventSinkImpl posts via the Events API, so whatever
+// ServiceAccount this binary runs as needs "create" (and "patch", for event
+// aggregation) on the "events" resource in every namespace it's asked to
+// watch, in addition to the "get"/"list"/"watch" on "secrets" the watcher
+// already requires.
+func NewCertificateEventEmitter(kubeClient kubernetes.Interface, component string) *CertificateEventEmitter {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: component})
+
+	return &CertificateEventEmitter{
+		recorder: recorder,
+		emitted:  make(map[string]bool),
+	}
+}
+
+// certificateThreshold classifies a CertificateInfo's expiry state into the
+// threshold it has crossed, or "" if it hasn't crossed any.
+func certificateThreshold(info *CertificateInfo, critDaysLeft, warnDaysLeft int) string {
+	switch {
+	case info.DaysLeft <= 0:
+		return "expired"
+	case info.DaysLeft <= critDaysLeft:
+		return "critical"
+	case info.DaysLeft < warnDaysLeft:
+		return "warn"
+	default:
+		return ""
+	}
+}
+
+// certificateState is like certificateThreshold but returns "ok" instead of
+// "" when no threshold has been crossed, for use as a state-machine label.
+func certificateState(info *CertificateInfo, critDaysLeft, warnDaysLeft int) string {
+	if state := certificateThreshold(info, critDaysLeft, warnDaysLeft); state != "" {
+		return state
+	}
+
+	return "ok"
+}
+
+// EmitForSecret records a v1.Event against secretRef if info has crossed a
+// warn/critical/expired threshold that hasn't already been reported for
+// this secret.
+func (e *CertificateEventEmitter) EmitForSecret(secretRef *v1.ObjectReference, info *CertificateInfo, critDaysLeft, warnDaysLeft int) {
+	threshold := certificateThreshold(info, critDaysLeft, warnDaysLeft)
+	if threshold == "" {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", secretRef.UID, threshold)
+
+	e.mu.Lock()
+	if e.emitted[key] {
+		e.mu.Unlock()
+		return
+	}
+	e.emitted[key] = true
+	e.mu.Unlock()
+
+	reason := ReasonCertificateExpiringSoon
+	if threshold == "expired" {
+		reason = ReasonCertificateExpired
+	}
+
+	message := fmt.Sprintf("certificate %s (%s) has %d days left, not after %s", info.SecretName, strings.Join(info.DNSNames, ", "), info.DaysLeft, info.NotAfter)
+	e.recorder.Event(secretRef, v1.EventTypeWarning, reason, message)
+}
+
+// Reset clears any recorded thresholds for a secret, e.g. after it has been
+// renewed, so a future threshold crossing is reported again.
+func (e *CertificateEventEmitter) Reset(uid types.UID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, threshold := range []string{"warn", "critical", "expired"} {
+		delete(e.emitted, fmt.Sprintf("%s/%s", uid, threshold))
+	}
+}
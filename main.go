@@ -13,8 +13,9 @@ import (
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/olesku/k8s-certmon/notifier"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -22,27 +23,58 @@ import (
 
 // ApplicationConfig Runtime configuration.
 type ApplicationConfig struct {
-	KubeConfig     string `envconfig:"KUBECONFIG" default:""`
-	UpdateInterval int    `envconfig:"UPDATE_INTERVAL" default:"60"`
-	ListenPort     int    `envconfig:"LISTEN_PORT" default:"8080"`
-	CritDaysLeft   int    `envconfig:"DAYS_LEFT_CRITICAL_THRESHOLD" default:"3"`
-	WarnDaysLeft   int    `envconfig:"DAYS_LEFT_WARN_THRESHOLD" default:"30"`
-	KubeClient     *kubernetes.Clientset
+	KubeConfig              string `envconfig:"KUBECONFIG" default:""`
+	UpdateInterval          int    `envconfig:"UPDATE_INTERVAL" default:"60"`
+	ListenPort              int    `envconfig:"LISTEN_PORT" default:"8080"`
+	CritDaysLeft            int    `envconfig:"DAYS_LEFT_CRITICAL_THRESHOLD" default:"3"`
+	WarnDaysLeft            int    `envconfig:"DAYS_LEFT_WARN_THRESHOLD" default:"30"`
+	CACritDaysLeft          int    `envconfig:"CA_DAYS_LEFT_CRITICAL_THRESHOLD" default:"30"`
+	CAWarnDaysLeft          int    `envconfig:"CA_DAYS_LEFT_WARN_THRESHOLD" default:"365"`
+	VerifyChain             bool   `envconfig:"VERIFY_CHAIN" default:"false"`
+	EnableMetrics           bool   `envconfig:"ENABLE_METRICS" default:"true"`
+	WatchNamespaces         string `envconfig:"WATCH_NAMESPACES" default:""`
+	EmitEvents              bool   `envconfig:"EMIT_EVENTS" default:"false"`
+	EventSourceComponent    string `envconfig:"EVENT_SOURCE_COMPONENT" default:"k8s-certmon"`
+	SlackWebhookURL         string `envconfig:"SLACK_WEBHOOK_URL" default:""`
+	WebhookURL              string `envconfig:"WEBHOOK_URL" default:""`
+	PagerdutyRoutingKey     string `envconfig:"PAGERDUTY_ROUTING_KEY" default:""`
+	EnableCertManagerSource bool   `envconfig:"ENABLE_CERTMANAGER_SOURCE" default:"false"`
+	EnableIngressSource     bool   `envconfig:"ENABLE_INGRESS_SOURCE" default:"false"`
+	EnableProbeSource       bool   `envconfig:"ENABLE_PROBE_SOURCE" default:"false"`
+	KubeClient              *kubernetes.Clientset
 }
 
+// CertificateKind identifies where a certificate sits in the chain, since
+// leaf and CA certificates are expected to have very different lifetimes.
+type CertificateKind string
+
+const (
+	CertificateKindLeaf         CertificateKind = "leaf"
+	CertificateKindIntermediate CertificateKind = "intermediate"
+	CertificateKindCA           CertificateKind = "ca"
+)
+
 // CertificateInfo holds information about a certificate.
 type CertificateInfo struct {
-	SecretName  string   `json:"secretName"`
-	Namespace   string   `json:"namespace"`
-	Issuer      string   `json:"issuer"`
-	CommonNames []string `json:"commonNames"`
-	NotBefore   string   `json:"notBefore"`
-	NotAfter    string   `json:"notAfter"`
-	DNSNames    []string `json:"dnsNames"`
-	DaysLeft    int      `json:"daysLeft" default:"0"`
-	IsValid     bool     `json:"isValid" default:"false"`
+	SecretName  string            `json:"secretName"`
+	Namespace   string            `json:"namespace"`
+	Source      string            `json:"source"`
+	Kind        CertificateKind   `json:"kind"`
+	Issuer      string            `json:"issuer"`
+	CommonNames []string          `json:"commonNames"`
+	NotBefore   string            `json:"notBefore"`
+	NotAfter    string            `json:"notAfter"`
+	DNSNames    []string          `json:"dnsNames"`
+	DaysLeft    int               `json:"daysLeft" default:"0"`
+	IsValid     bool              `json:"isValid" default:"false"`
+	Extra       map[string]string `json:"extra,omitempty"`
 }
 
+// SourceSecret is the CertificateInfo.Source value for certificates
+// discovered by scanning kubernetes.io/tls secrets directly, as opposed to
+// one of the pluggable sources in the sources package.
+const SourceSecret = "secret"
+
 // StatusResponse holds the data for the JSON status response returned by this API.
 type StatusResponse struct {
 	LastUpdated  string             `json:"lastUpdated"`
@@ -51,20 +83,18 @@ type StatusResponse struct {
 	Certificates []*CertificateInfo `json:"certificates"`
 }
 
-func newKubernetesClient(appConfig *ApplicationConfig) (*kubernetes.Clientset, error) {
-	var config *rest.Config
-	var err error
-
+func newRestConfig(appConfig *ApplicationConfig) (*rest.Config, error) {
 	if appConfig.KubeConfig != "" {
-		config, err = clientcmd.BuildConfigFromFlags("", appConfig.KubeConfig)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		config, err = rest.InClusterConfig()
-		if err != nil {
-			return nil, err
-		}
+		return clientcmd.BuildConfigFromFlags("", appConfig.KubeConfig)
+	}
+
+	return rest.InClusterConfig()
+}
+
+func newKubernetesClient(appConfig *ApplicationConfig) (*kubernetes.Clientset, error) {
+	config, err := newRestConfig(appConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
@@ -75,107 +105,180 @@ func newKubernetesClient(appConfig *ApplicationConfig) (*kubernetes.Clientset, e
 	return clientset, nil
 }
 
-// Parse a kubernetes secret as a PEM certificate and extract information.
-// Returns a x509.Certificate object.
-func getx509Data(client *kubernetes.Clientset, secret v1.Secret) (*x509.Certificate, error) {
+// Decode every PEM block in data into a x509.Certificate, ignoring any
+// non-CERTIFICATE blocks (e.g. private keys that ended up in the same file).
+func decodeCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
 
+	return certs, nil
+}
+
+// Parse a kubernetes TLS secret into its certificate chain, plus any
+// separately bundled CA certificates under ca.crt.
+// chain[0] is the leaf certificate, chain[1:] are intermediates.
+func getx509Data(secret v1.Secret) (chain []*x509.Certificate, caCerts []*x509.Certificate, err error) {
 	tlsCrt, ok := secret.Data["tls.crt"]
 	if !ok {
-		return nil, fmt.Errorf("tls.crt does not exist in %s/%s", secret.Namespace, secret.Name)
+		return nil, nil, fmt.Errorf("tls.crt does not exist in %s/%s", secret.Namespace, secret.Name)
 	}
 
 	if len(tlsCrt) == 0 {
-		return nil, fmt.Errorf("tls.crt for %s/%s is empty", secret.Namespace, secret.Name)
+		return nil, nil, fmt.Errorf("tls.crt for %s/%s is empty", secret.Namespace, secret.Name)
 	}
 
-	block, _ := pem.Decode(tlsCrt)
-	if block == nil {
-		return nil, fmt.Errorf("Failed to decode certificate %s/%s", secret.Namespace, secret.Name)
-	}
-
-	parsedCert, err := x509.ParseCertificate(block.Bytes)
+	chain, err = decodeCertificates(tlsCrt)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse certificate %s/%s", secret.Namespace, secret.Name)
+		return nil, nil, fmt.Errorf("Failed to parse certificate %s/%s: %s", secret.Namespace, secret.Name, err.Error())
 	}
 
-	return parsedCert, nil
-}
-
-// Get a list of tls secrets and its certificate data in the cluster.
-func getCertificateList(appConfig *ApplicationConfig) (certificateInfoList []*CertificateInfo, warnings []string, errors []string) {
-	namespaces, err := appConfig.KubeClient.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		errors = append(errors, err.Error())
-		return nil, warnings, errors
+	if len(chain) == 0 {
+		return nil, nil, fmt.Errorf("Failed to decode certificate %s/%s", secret.Namespace, secret.Name)
 	}
 
-	for _, ns := range namespaces.Items {
-		secrets, _ := appConfig.KubeClient.CoreV1().Secrets(ns.Name).List(context.Background(), metav1.ListOptions{})
+	if caCrt, ok := secret.Data["ca.crt"]; ok && len(caCrt) > 0 {
+		caCerts, err = decodeCertificates(caCrt)
 		if err != nil {
-			continue
+			return nil, nil, fmt.Errorf("Failed to parse ca.crt %s/%s: %s", secret.Namespace, secret.Name, err.Error())
 		}
+	}
 
-		for _, secret := range secrets.Items {
-			if secret.Type != "kubernetes.io/tls" {
-				continue
-			}
+	return chain, caCerts, nil
+}
 
-			if originNS, ok := secret.Labels["kubed.appscode.com/origin.namespace"]; ok {
-				if originNS != ns.Name {
-					continue
-				}
-			}
+// verifyChain builds a chain from the leaf up through the intermediates and
+// CA bundle, returning an error describing why verification failed, if any.
+func verifyChain(leaf *x509.Certificate, intermediates []*x509.Certificate, caCerts []*x509.Certificate) error {
+	intermediatePool := x509.NewCertPool()
+	for _, cert := range intermediates {
+		intermediatePool.AddCert(cert)
+	}
 
-			certificateInfo := &CertificateInfo{
-				SecretName: secret.Name,
-				Namespace:  ns.Name,
-			}
+	rootPool := x509.NewCertPool()
+	for _, cert := range caCerts {
+		rootPool.AddCert(cert)
+	}
 
-			x509Data, err := getx509Data(appConfig.KubeClient, secret)
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Intermediates: intermediatePool,
+		Roots:         rootPool,
+	})
 
-			if err != nil {
-				errors = append(errors, err.Error())
-				continue
-			} else {
-				daysLeft := (x509Data.NotAfter.Unix() - time.Now().Unix()) / 86400
+	return err
+}
 
-				certificateInfo.NotBefore = x509Data.NotBefore.String()
-				certificateInfo.NotAfter = x509Data.NotAfter.String()
-				certificateInfo.Issuer = x509Data.Issuer.CommonName
-				certificateInfo.DNSNames = x509Data.DNSNames
-				certificateInfo.DaysLeft = int(daysLeft)
-				certificateInfo.IsValid = true
+// toCertificateInfo evaluates a parsed certificate against the relevant
+// crit/warn thresholds and turns it into a CertificateInfo, appending any
+// warning/error message it produces to the given slices.
+func toCertificateInfo(ns, secretName string, cert *x509.Certificate, kind CertificateKind, critDaysLeft, warnDaysLeft int, warnings, errors []string) (*CertificateInfo, []string, []string) {
+	daysLeft := (cert.NotAfter.Unix() - time.Now().Unix()) / 86400
+
+	info := &CertificateInfo{
+		SecretName: secretName,
+		Namespace:  ns,
+		Source:     SourceSecret,
+		Kind:       kind,
+		NotBefore:  cert.NotBefore.String(),
+		NotAfter:   cert.NotAfter.String(),
+		Issuer:     cert.Issuer.CommonName,
+		DNSNames:   cert.DNSNames,
+		DaysLeft:   int(daysLeft),
+		IsValid:    true,
+	}
 
-				for _, n := range x509Data.Subject.Names {
-					certificateInfo.CommonNames = append(certificateInfo.CommonNames, fmt.Sprintf("%v", n.Value))
-				}
+	for _, n := range cert.Subject.Names {
+		info.CommonNames = append(info.CommonNames, fmt.Sprintf("%v", n.Value))
+	}
 
-				if daysLeft <= 0 {
-					errors = append(errors, fmt.Sprintf("certificate %s/%s (%s) expired on %s", ns.Name, certificateInfo.SecretName, strings.Join(certificateInfo.DNSNames, ", "), certificateInfo.NotAfter))
-					certificateInfo.IsValid = false
-				} else if daysLeft <= int64(appConfig.CritDaysLeft) {
-					errors = append(errors, fmt.Sprintf("certificate %s/%s (%s) will expire in %d days (%s).", ns.Name, certificateInfo.SecretName, strings.Join(certificateInfo.DNSNames, ", "), certificateInfo.DaysLeft, certificateInfo.NotAfter))
-				} else if daysLeft < int64(appConfig.WarnDaysLeft) {
-					warnings = append(warnings, fmt.Sprintf("certificate %s/%s (%s) will expire in %d days (%s).", ns.Name, certificateInfo.SecretName, strings.Join(certificateInfo.DNSNames, ", "), certificateInfo.DaysLeft, certificateInfo.NotAfter))
-				}
-			}
+	if daysLeft <= 0 {
+		errors = append(errors, fmt.Sprintf("%s certificate %s/%s (%s) expired on %s", kind, ns, info.SecretName, strings.Join(info.DNSNames, ", "), info.NotAfter))
+		info.IsValid = false
+	} else if daysLeft <= int64(critDaysLeft) {
+		errors = append(errors, fmt.Sprintf("%s certificate %s/%s (%s) will expire in %d days (%s).", kind, ns, info.SecretName, strings.Join(info.DNSNames, ", "), info.DaysLeft, info.NotAfter))
+	} else if daysLeft < int64(warnDaysLeft) {
+		warnings = append(warnings, fmt.Sprintf("%s certificate %s/%s (%s) will expire in %d days (%s).", kind, ns, info.SecretName, strings.Join(info.DNSNames, ", "), info.DaysLeft, info.NotAfter))
+	}
+
+	return info, warnings, errors
+}
 
-			certificateInfoList = append(certificateInfoList, certificateInfo)
+// evaluateCertificateChain turns an already-parsed certificate chain (leaf
+// plus intermediates) and CA bundle into CertificateInfo entries, applying
+// leaf thresholds to the leaf and CA thresholds to everything else. It is
+// shared by the informer-backed daemon path and the one-shot check command.
+func evaluateCertificateChain(ns, secretName string, chain, caCerts []*x509.Certificate, appConfig *ApplicationConfig, warnings, errors []string) (certs []*CertificateInfo, newWarnings, newErrors []string) {
+	// Only attempt chain verification when there's an explicit CA bundle to
+	// verify against: an empty x509.CertPool is not the same as "fall back
+	// to the system trust store", so without a ca.crt this would otherwise
+	// fail "unknown authority" for every normal, non-bundled certificate.
+	if appConfig.VerifyChain && len(caCerts) > 0 {
+		if err := verifyChain(chain[0], chain[1:], caCerts); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to verify certificate chain for %s/%s: %s", ns, secretName, err.Error()))
 		}
 	}
 
-	if warnings == nil {
-		warnings = make([]string, 0)
+	var leafInfo *CertificateInfo
+	leafInfo, warnings, errors = toCertificateInfo(ns, secretName, chain[0], CertificateKindLeaf, appConfig.CritDaysLeft, appConfig.WarnDaysLeft, warnings, errors)
+	certs = append(certs, leafInfo)
+
+	for _, intermediate := range chain[1:] {
+		var info *CertificateInfo
+		info, warnings, errors = toCertificateInfo(ns, secretName, intermediate, CertificateKindIntermediate, appConfig.CACritDaysLeft, appConfig.CAWarnDaysLeft, warnings, errors)
+		certs = append(certs, info)
 	}
 
-	if errors == nil {
-		errors = make([]string, 0)
+	for _, ca := range caCerts {
+		var info *CertificateInfo
+		info, warnings, errors = toCertificateInfo(ns, secretName, ca, CertificateKindCA, appConfig.CACritDaysLeft, appConfig.CAWarnDaysLeft, warnings, errors)
+		certs = append(certs, info)
 	}
 
-	return certificateInfoList, warnings, errors
+	return certs, warnings, errors
+}
+
+// buildNotifiers constructs a Notifier for every sink the operator has
+// configured via environment variables.
+func buildNotifiers(appConfig *ApplicationConfig) []notifier.Notifier {
+	var sinks []notifier.Notifier
+
+	if appConfig.SlackWebhookURL != "" {
+		sinks = append(sinks, notifier.NewSlackNotifier(appConfig.SlackWebhookURL))
+	}
+
+	if appConfig.WebhookURL != "" {
+		sinks = append(sinks, notifier.NewWebhookNotifier(appConfig.WebhookURL))
+	}
+
+	if appConfig.PagerdutyRoutingKey != "" {
+		sinks = append(sinks, notifier.NewPagerDutyNotifier(appConfig.PagerdutyRoutingKey))
+	}
+
+	return sinks
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheckCommand(os.Args[2:]))
+	}
+
 	var appConfig ApplicationConfig
 	err := envconfig.Process("", &appConfig)
 
@@ -193,12 +296,28 @@ func main() {
 
 	var currentStatus StatusResponse
 
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	watcher := NewCertificateWatcher(&appConfig)
+	if appConfig.EmitEvents {
+		watcher.eventEmitter = NewCertificateEventEmitter(appConfig.KubeClient, appConfig.EventSourceComponent)
+	}
+
+	log.Printf("Starting secret informer(s).\n")
+	if err := watcher.Start(stopCh); err != nil {
+		log.Fatalf("Error starting secret informer: %s\n", err.Error())
+	}
+
+	notifySinks := buildNotifiers(&appConfig)
+	extraCollectors := buildExtraCollectors(&appConfig)
+
 	go func() {
 		for {
 			start := time.Now().Unix()
 
-			log.Printf("Fetching secrets with certificate data.\n")
-			certList, warnings, errors := getCertificateList(&appConfig)
+			certList, warnings, errors := watcher.GetCertificateList()
+			certList, errors = collectExtraCertificates(context.Background(), extraCollectors, certList, errors)
 			currentStatus = StatusResponse{
 				LastUpdated:  time.Now().String(),
 				Errors:       errors,
@@ -206,6 +325,16 @@ func main() {
 				Certificates: certList,
 			}
 
+			if appConfig.EnableMetrics {
+				updateCertificateMetrics(certList, errors, time.Now().Unix())
+			}
+
+			if transitions := watcher.ComputeTransitions(certList); len(transitions) > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				notifier.Dispatch(ctx, notifySinks, transitions)
+				cancel()
+			}
+
 			if len(errors) > 0 {
 				for _, err := range errors {
 					log.Printf("Error: %s\n", err)
@@ -220,14 +349,15 @@ func main() {
 
 			stop := time.Now().Unix()
 
-			log.Printf("Fetched %d tls secrets in %d seconds.\n\n", len(certList), stop-start)
+			log.Printf("Recomputed expiry for %d certificates in %d seconds.\n\n", len(certList), stop-start)
 			time.Sleep(time.Duration(appConfig.UpdateInterval) * time.Second)
 		}
 	}()
 
 	log.Printf("Starting server on port %d\n", appConfig.ListenPort)
 
-	err = http.ListenAndServe(fmt.Sprintf(":%d", appConfig.ListenPort), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "application/json")
 
 		if len(currentStatus.Errors) > 0 {
@@ -245,8 +375,14 @@ func main() {
 		}
 
 		w.Write(j)
-	}))
+	})
+
+	if appConfig.EnableMetrics {
+		log.Printf("Exposing Prometheus metrics on /metrics\n")
+		mux.Handle("/metrics", promhttp.Handler())
+	}
 
+	err = http.ListenAndServe(fmt.Sprintf(":%d", appConfig.ListenPort), mux)
 	if err != nil {
 		log.Fatalf("Failed to start webserver on port %d: %s\n", appConfig.ListenPort, err.Error())
 	}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// selfSignedCert builds a throwaway self-signed certificate valid until
+// notAfter, for exercising threshold logic without touching the network.
+func selfSignedCert(t *testing.T, commonName string, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	return cert
+}
+
+// TestEvaluateCertificateChainThresholdSplit asserts leaf certs are judged
+// against CritDaysLeft/WarnDaysLeft and CA certs against the (normally much
+// longer) CACritDaysLeft/CAWarnDaysLeft, rather than one threshold pair
+// being applied to the whole chain.
+func TestEvaluateCertificateChainThresholdSplit(t *testing.T) {
+	appConfig := &ApplicationConfig{
+		CritDaysLeft:   3,
+		WarnDaysLeft:   30,
+		CACritDaysLeft: 30,
+		CAWarnDaysLeft: 365,
+	}
+
+	now := time.Now()
+	// 60 days left: clears leaf thresholds, but still inside the CA warn window.
+	leaf := selfSignedCert(t, "leaf", now.Add(60*24*time.Hour))
+	ca := selfSignedCert(t, "ca", now.Add(60*24*time.Hour))
+
+	certs, warnings, errors := evaluateCertificateChain("default", "example-tls", []*x509.Certificate{leaf}, []*x509.Certificate{ca}, appConfig, nil, nil)
+
+	if len(errors) != 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+
+	if len(certs) != 2 {
+		t.Fatalf("expected 2 CertificateInfo entries, got %d", len(certs))
+	}
+
+	if certs[0].Kind != CertificateKindLeaf || certs[0].IsValid != true {
+		t.Errorf("leaf entry unexpectedly flagged: %+v", certs[0])
+	}
+
+	if certs[1].Kind != CertificateKindCA || certs[1].IsValid != true {
+		t.Errorf("ca entry unexpectedly invalid: %+v", certs[1])
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected exactly one warning for the CA cert inside its warn window, got %v", warnings)
+	}
+}
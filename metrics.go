@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	certificateExpirySeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "certmon_certificate_expiry_seconds",
+		Help: "Remaining validity of the observed certificate, in seconds, rounded down to whole days.",
+	}, []string{"namespace", "secret", "issuer", "common_name", "kind", "source"})
+
+	scrapeErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "certmon_scrape_errors_total",
+		Help: "Total number of errors encountered while scraping secrets for certificate data.",
+	})
+
+	lastScrapeTimestampSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "certmon_last_scrape_timestamp_seconds",
+		Help: "Unix timestamp of the last completed scrape.",
+	})
+)
+
+// updateCertificateMetrics refreshes the Prometheus gauges from a freshly
+// fetched certificate list, clearing any label sets left over from secrets
+// that have since been deleted or renamed.
+func updateCertificateMetrics(certList []*CertificateInfo, errors []string, scrapeTime int64) {
+	certificateExpirySeconds.Reset()
+
+	for _, cert := range certList {
+		commonName := ""
+		if len(cert.CommonNames) > 0 {
+			commonName = cert.CommonNames[0]
+		}
+
+		certificateExpirySeconds.
+			WithLabelValues(cert.Namespace, cert.SecretName, cert.Issuer, commonName, string(cert.Kind), cert.Source).
+			Set(float64(cert.DaysLeft) * 86400)
+	}
+
+	scrapeErrorsTotal.Add(float64(len(errors)))
+	lastScrapeTimestampSeconds.Set(float64(scrapeTime))
+}
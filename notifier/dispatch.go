@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	maxNotifyAttempts = 3
+	notifyBaseBackoff = 2 * time.Second
+)
+
+// Dispatch fans events out to every sink concurrently, retrying each sink
+// independently with exponential backoff before giving up on it.
+func Dispatch(ctx context.Context, sinks []Notifier, events []CertificateEvent) {
+	if len(events) == 0 || len(sinks) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, sink := range sinks {
+		wg.Add(1)
+		go func(sink Notifier) {
+			defer wg.Done()
+			notifyWithRetry(ctx, sink, events)
+		}(sink)
+	}
+
+	wg.Wait()
+}
+
+func notifyWithRetry(ctx context.Context, sink Notifier, events []CertificateEvent) {
+	backoff := notifyBaseBackoff
+
+	for attempt := 1; attempt <= maxNotifyAttempts; attempt++ {
+		err := sink.Notify(ctx, events)
+		if err == nil {
+			return
+		}
+
+		log.Printf("Error: notifier attempt %d/%d failed: %s\n", attempt, maxNotifyAttempts, err.Error())
+
+		if attempt == maxNotifyAttempts {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
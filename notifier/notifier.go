@@ -0,0 +1,24 @@
+// Package notifier defines the push-notification sinks k8s-certmon can
+// report certificate state transitions to (Slack, generic webhooks,
+// PagerDuty), independent of how the transitions themselves are computed.
+package notifier
+
+import "context"
+
+// CertificateEvent describes a single state transition for a certificate,
+// e.g. "ok" -> "warn" or "critical" -> "expired".
+type CertificateEvent struct {
+	Namespace  string
+	SecretName string
+	Kind       string
+	CommonName string
+	DaysLeft   int
+	NotAfter   string
+	FromState  string
+	ToState    string
+}
+
+// Notifier delivers a batch of certificate state transitions to a sink.
+type Notifier interface {
+	Notify(ctx context.Context, events []CertificateEvent) error
+}
@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyNotifier triggers a PagerDuty Events API v2 incident per
+// certificate state transition. Transitions back to "ok"/"renewed" are sent
+// as a "resolve" action so the matching incident auto-resolves.
+type PagerDutyNotifier struct {
+	RoutingKey string
+	HTTPClient *http.Client
+}
+
+// NewPagerDutyNotifier returns a PagerDutyNotifier for the given routing key.
+func NewPagerDutyNotifier(routingKey string) *PagerDutyNotifier {
+	return &PagerDutyNotifier{
+		RoutingKey: routingKey,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string               `json:"routing_key"`
+	EventAction string               `json:"event_action"`
+	DedupKey    string               `json:"dedup_key"`
+	Payload     pagerDutyEventDetail `json:"payload,omitempty"`
+}
+
+type pagerDutyEventDetail struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+func (p *PagerDutyNotifier) Notify(ctx context.Context, events []CertificateEvent) error {
+	for _, e := range events {
+		if err := p.notifyOne(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *PagerDutyNotifier) notifyOne(ctx context.Context, e CertificateEvent) error {
+	action := "trigger"
+	severity := "warning"
+
+	switch e.ToState {
+	case "critical", "expired":
+		severity = "critical"
+	case "ok", "renewed":
+		action = "resolve"
+	}
+
+	event := pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: action,
+		DedupKey:    fmt.Sprintf("%s/%s/%s", e.Namespace, e.SecretName, e.Kind),
+		Payload: pagerDutyEventDetail{
+			Summary:  fmt.Sprintf("certificate %s/%s (%s) is %s, %d days left (not after %s)", e.Namespace, e.SecretName, e.CommonName, e.ToState, e.DaysLeft, e.NotAfter),
+			Source:   fmt.Sprintf("%s/%s", e.Namespace, e.SecretName),
+			Severity: severity,
+		},
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pagerDutyEventsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty events API returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SlackNotifier posts certificate state transitions to a Slack incoming
+// webhook.
+type SlackNotifier struct {
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+// NewSlackNotifier returns a SlackNotifier that posts to webhookURL.
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, events []CertificateEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var lines []string
+	for _, e := range events {
+		lines = append(lines, fmt.Sprintf("%s/%s (%s, %s): %s -> %s, %d days left (not after %s)",
+			e.Namespace, e.SecretName, e.Kind, e.CommonName, e.FromState, e.ToState, e.DaysLeft, e.NotAfter))
+	}
+
+	body, err := json.Marshal(slackMessage{Text: strings.Join(lines, "\n")})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier POSTs the raw list of certificate state transitions as
+// JSON to a generic HTTP endpoint.
+type WebhookNotifier struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewWebhookNotifier returns a WebhookNotifier that posts to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:        url,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, events []CertificateEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", w.URL, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var certificateGVR = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+
+// CertManagerCollector lists cert-manager.io/v1 Certificate resources and
+// correlates each with its target Secret, surfacing the CR's Ready/Issuing
+// conditions and renewal time alongside the parsed leaf certificate.
+type CertManagerCollector struct {
+	Dynamic   dynamic.Interface
+	Kube      kubernetes.Interface
+	Namespace string
+}
+
+// NewCertManagerCollector returns a collector scoped to namespace, or every
+// namespace if namespace is "".
+func NewCertManagerCollector(dyn dynamic.Interface, kube kubernetes.Interface, namespace string) *CertManagerCollector {
+	return &CertManagerCollector{Dynamic: dyn, Kube: kube, Namespace: namespace}
+}
+
+func (c *CertManagerCollector) Name() string {
+	return "cert-manager"
+}
+
+func (c *CertManagerCollector) Collect(ctx context.Context) ([]CertificateInfo, error) {
+	list, err := c.Dynamic.Resource(certificateGVR).Namespace(c.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cert-manager Certificates: %w", err)
+	}
+
+	var results []CertificateInfo
+	for _, item := range list.Items {
+		ns := item.GetNamespace()
+
+		secretName, _, _ := unstructured.NestedString(item.Object, "spec", "secretName")
+		if secretName == "" {
+			secretName = item.GetName()
+		}
+
+		ready, issuing := certManagerConditions(item.Object)
+		renewalTime, _, _ := unstructured.NestedString(item.Object, "status", "renewalTime")
+
+		info := CertificateInfo{
+			Source:     c.Name(),
+			Namespace:  ns,
+			SecretName: secretName,
+			Kind:       "leaf",
+			Extra: map[string]string{
+				"certificate": item.GetName(),
+				"ready":       strconv.FormatBool(ready),
+				"issuing":     strconv.FormatBool(issuing),
+				"renewalTime": renewalTime,
+			},
+		}
+
+		if secret, err := c.Kube.CoreV1().Secrets(ns).Get(ctx, secretName, metav1.GetOptions{}); err == nil {
+			if leaf, err := decodeLeafCertificate(secret.Data["tls.crt"]); err == nil && leaf != nil {
+				daysLeft := (leaf.NotAfter.Unix() - time.Now().Unix()) / 86400
+
+				info.Issuer = leaf.Issuer.CommonName
+				info.CommonNames = []string{leaf.Subject.CommonName}
+				info.DNSNames = leaf.DNSNames
+				info.NotBefore = leaf.NotBefore.String()
+				info.NotAfter = leaf.NotAfter.String()
+				info.DaysLeft = int(daysLeft)
+				info.IsValid = daysLeft > 0
+			}
+		}
+
+		results = append(results, info)
+	}
+
+	return results, nil
+}
+
+func certManagerConditions(obj map[string]interface{}) (ready, issuing bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+
+	for _, raw := range conditions {
+		condition, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		condType, _ := condition["type"].(string)
+		status, _ := condition["status"].(string)
+
+		switch condType {
+		case "Ready":
+			ready = status == "True"
+		case "Issuing":
+			issuing = status == "True"
+		}
+	}
+
+	return ready, issuing
+}
@@ -0,0 +1,79 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// IngressCollector resolves networking.k8s.io/v1 Ingress TLS entries to
+// their referenced secrets, so certificates are reported per hostname
+// rather than per secret.
+type IngressCollector struct {
+	Kube      kubernetes.Interface
+	Namespace string
+}
+
+// NewIngressCollector returns a collector scoped to namespace, or every
+// namespace if namespace is "".
+func NewIngressCollector(kube kubernetes.Interface, namespace string) *IngressCollector {
+	return &IngressCollector{Kube: kube, Namespace: namespace}
+}
+
+func (c *IngressCollector) Name() string {
+	return "ingress"
+}
+
+func (c *IngressCollector) Collect(ctx context.Context) ([]CertificateInfo, error) {
+	ingresses, err := c.Kube.NetworkingV1().Ingresses(c.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	var results []CertificateInfo
+	for _, ingress := range ingresses.Items {
+		for _, tlsEntry := range ingress.Spec.TLS {
+			secret, err := c.Kube.CoreV1().Secrets(ingress.Namespace).Get(ctx, tlsEntry.SecretName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+
+			leaf, err := decodeLeafCertificate(secret.Data["tls.crt"])
+			if err != nil || leaf == nil {
+				continue
+			}
+
+			daysLeft := (leaf.NotAfter.Unix() - time.Now().Unix()) / 86400
+
+			hosts := tlsEntry.Hosts
+			if len(hosts) == 0 {
+				hosts = []string{""}
+			}
+
+			for _, host := range hosts {
+				results = append(results, CertificateInfo{
+					Source:      c.Name(),
+					Namespace:   ingress.Namespace,
+					SecretName:  tlsEntry.SecretName,
+					Kind:        "leaf",
+					Issuer:      leaf.Issuer.CommonName,
+					CommonNames: []string{leaf.Subject.CommonName},
+					DNSNames:    leaf.DNSNames,
+					NotBefore:   leaf.NotBefore.String(),
+					NotAfter:    leaf.NotAfter.String(),
+					DaysLeft:    int(daysLeft),
+					IsValid:     daysLeft > 0,
+					Extra: map[string]string{
+						"ingress": ingress.Name,
+						"host":    host,
+					},
+				})
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,27 @@
+package sources
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+)
+
+// decodeLeafCertificate returns the first CERTIFICATE block in data. Sources
+// only need the leaf's expiry, so unlike main's decodeCertificates this
+// doesn't bother collecting the rest of the chain.
+func decodeLeafCertificate(data []byte) (*x509.Certificate, error) {
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, nil
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		return x509.ParseCertificate(block.Bytes)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ProbeCollector dials host:443 for each Ingress host and compares the
+// served leaf certificate's fingerprint against the one stored in the
+// referenced secret, catching cases where the ingress controller is
+// serving a stale certificate.
+type ProbeCollector struct {
+	Kube        kubernetes.Interface
+	Namespace   string
+	DialTimeout time.Duration
+}
+
+// NewProbeCollector returns a collector scoped to namespace, or every
+// namespace if namespace is "".
+func NewProbeCollector(kube kubernetes.Interface, namespace string) *ProbeCollector {
+	return &ProbeCollector{Kube: kube, Namespace: namespace, DialTimeout: 5 * time.Second}
+}
+
+func (c *ProbeCollector) Name() string {
+	return "probe"
+}
+
+func (c *ProbeCollector) Collect(ctx context.Context) ([]CertificateInfo, error) {
+	ingresses, err := c.Kube.NetworkingV1().Ingresses(c.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	var results []CertificateInfo
+	for _, ingress := range ingresses.Items {
+		for _, tlsEntry := range ingress.Spec.TLS {
+			secret, err := c.Kube.CoreV1().Secrets(ingress.Namespace).Get(ctx, tlsEntry.SecretName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+
+			storedLeaf, err := decodeLeafCertificate(secret.Data["tls.crt"])
+			if err != nil || storedLeaf == nil {
+				continue
+			}
+
+			for _, host := range tlsEntry.Hosts {
+				results = append(results, c.probeHost(ingress.Namespace, tlsEntry.SecretName, host, storedLeaf))
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (c *ProbeCollector) probeHost(namespace, secretName, host string, storedLeaf *x509.Certificate) CertificateInfo {
+	info := CertificateInfo{
+		Source:      c.Name(),
+		Namespace:   namespace,
+		SecretName:  secretName,
+		Kind:        "leaf",
+		CommonNames: []string{storedLeaf.Subject.CommonName},
+		Extra: map[string]string{
+			"host": host,
+		},
+	}
+
+	servedLeaf, err := dialLeafCertificate(host, c.DialTimeout)
+	if err != nil {
+		info.Extra["error"] = err.Error()
+		return info
+	}
+
+	daysLeft := (servedLeaf.NotAfter.Unix() - time.Now().Unix()) / 86400
+
+	info.Issuer = servedLeaf.Issuer.CommonName
+	info.DNSNames = servedLeaf.DNSNames
+	info.NotBefore = servedLeaf.NotBefore.String()
+	info.NotAfter = servedLeaf.NotAfter.String()
+	info.DaysLeft = int(daysLeft)
+	info.IsValid = daysLeft > 0
+
+	stale := fingerprint(servedLeaf) != fingerprint(storedLeaf)
+	info.Extra["stale"] = strconv.FormatBool(stale)
+
+	return info
+}
+
+func dialLeafCertificate(host string, timeout time.Duration) (*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, "443"), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	peerCerts := conn.ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return nil, fmt.Errorf("no certificate served by %s", host)
+	}
+
+	return peerCerts[0], nil
+}
+
+func fingerprint(cert *x509.Certificate) [32]byte {
+	return sha256.Sum256(cert.Raw)
+}
@@ -0,0 +1,31 @@
+// Package sources discovers certificates beyond plain kubernetes.io/tls
+// secrets: cert-manager Certificate resources, Ingress TLS entries, and
+// (optionally) the certificate an Ingress host actually serves.
+package sources
+
+import "context"
+
+// CertificateInfo is a source's view of a single certificate. Collectors
+// populate as much of this as they reasonably can; callers merge it into
+// their own richer representation, keyed by Source.
+type CertificateInfo struct {
+	Source      string
+	Namespace   string
+	SecretName  string
+	Kind        string
+	Issuer      string
+	CommonNames []string
+	DNSNames    []string
+	NotBefore   string
+	NotAfter    string
+	DaysLeft    int
+	IsValid     bool
+	Extra       map[string]string
+}
+
+// Collector discovers certificates from one additional source.
+type Collector interface {
+	// Name identifies the source, used as CertificateInfo.Source.
+	Name() string
+	Collect(ctx context.Context) ([]CertificateInfo, error)
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/olesku/k8s-certmon/notifier"
+)
+
+// cachedCertificate is the parsed, chain-split representation of a single
+// kubernetes.io/tls secret, kept around so re-scans don't need to re-parse
+// PEM data that hasn't changed since the last informer event.
+type cachedCertificate struct {
+	namespace       string
+	secretName      string
+	uid             types.UID
+	resourceVersion string
+	chain           []*x509.Certificate
+	caCerts         []*x509.Certificate
+}
+
+// CertificateWatcher maintains an in-memory view of every kubernetes.io/tls
+// secret in the watched namespace(s), kept in sync via shared informers
+// instead of polling the API server on every scan.
+type CertificateWatcher struct {
+	appConfig    *ApplicationConfig
+	eventEmitter *CertificateEventEmitter
+
+	mu    sync.RWMutex
+	certs map[string]*cachedCertificate
+
+	stateMu        sync.Mutex
+	previousStates map[string]string
+}
+
+func secretCacheKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// NewCertificateWatcher creates a watcher for the given configuration. Call
+// Start to begin populating it.
+func NewCertificateWatcher(appConfig *ApplicationConfig) *CertificateWatcher {
+	return &CertificateWatcher{
+		appConfig:      appConfig,
+		certs:          make(map[string]*cachedCertificate),
+		previousStates: make(map[string]string),
+	}
+}
+
+// Start registers shared informers for v1.Secret, either cluster-wide or
+// scoped to appConfig.WatchNamespaces, and begins processing events. It
+// returns once the informer caches have completed their initial sync.
+func (w *CertificateWatcher) Start(stopCh <-chan struct{}) error {
+	namespaces := w.watchedNamespaces()
+	resync := time.Duration(w.appConfig.UpdateInterval) * time.Second
+
+	for _, ns := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(w.appConfig.KubeClient, resync, informers.WithNamespace(ns))
+		informer := factory.Core().V1().Secrets().Informer()
+
+		_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    w.onAddOrUpdate,
+			UpdateFunc: func(_, newObj interface{}) { w.onAddOrUpdate(newObj) },
+			DeleteFunc: w.onDelete,
+		})
+		if err != nil {
+			return err
+		}
+
+		factory.Start(stopCh)
+		if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+			return fmt.Errorf("failed to sync secret informer for namespace %q", ns)
+		}
+	}
+
+	return nil
+}
+
+// watchedNamespaces returns the namespaces to scope informers to. A single
+// "" entry means "all namespaces", matching the shared informer factory's own
+// convention for informers.WithNamespace.
+func (w *CertificateWatcher) watchedNamespaces() []string {
+	return splitWatchNamespaces(w.appConfig.WatchNamespaces)
+}
+
+// splitWatchNamespaces parses the comma-separated WATCH_NAMESPACES config
+// value into a namespace list, returning []string{""} (cluster-wide) when
+// it's empty. Shared by anything that needs to scope a client-go call the
+// same way the secret informers are scoped, so a namespace-scoped
+// Role/RoleBinding deployment (see chunk0-3) stays namespace-scoped once
+// other sources of certificates are enabled.
+func splitWatchNamespaces(watchNamespaces string) []string {
+	if watchNamespaces == "" {
+		return []string{""}
+	}
+
+	var namespaces []string
+	for _, ns := range strings.Split(watchNamespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	return namespaces
+}
+
+func (w *CertificateWatcher) onAddOrUpdate(obj interface{}) {
+	secret, ok := obj.(*v1.Secret)
+	if !ok || secret.Type != "kubernetes.io/tls" {
+		return
+	}
+
+	if originNS, ok := secret.Labels["kubed.appscode.com/origin.namespace"]; ok && originNS != secret.Namespace {
+		return
+	}
+
+	key := secretCacheKey(secret.Namespace, secret.Name)
+
+	w.mu.RLock()
+	cached, exists := w.certs[key]
+	w.mu.RUnlock()
+
+	if exists && cached.resourceVersion == secret.ResourceVersion {
+		return
+	}
+
+	chain, caCerts, err := getx509Data(*secret)
+	if err != nil {
+		log.Printf("Error: %s\n", err.Error())
+		return
+	}
+
+	// The ResourceVersion bump that brought us here may just be metadata
+	// churn (annotations, relabeling, a replicator resync) rather than an
+	// actual tls.crt/ca.crt change. Only re-arm the dedup map when the
+	// certificate content itself changed, so re-scans don't spam duplicate
+	// events for a cert that's been sitting in the same window the whole time.
+	if exists && w.eventEmitter != nil && !certChainEqual(cached.chain, chain, cached.caCerts, caCerts) {
+		w.eventEmitter.Reset(secret.UID)
+	}
+
+	w.mu.Lock()
+	w.certs[key] = &cachedCertificate{
+		namespace:       secret.Namespace,
+		secretName:      secret.Name,
+		uid:             secret.UID,
+		resourceVersion: secret.ResourceVersion,
+		chain:           chain,
+		caCerts:         caCerts,
+	}
+	w.mu.Unlock()
+}
+
+// certChainEqual reports whether two chain/caCerts pairs are byte-for-byte
+// identical, comparing each certificate's raw DER encoding in order.
+func certChainEqual(chainA, chainB, caCertsA, caCertsB []*x509.Certificate) bool {
+	return certsEqual(chainA, chainB) && certsEqual(caCertsA, caCertsB)
+}
+
+func certsEqual(a, b []*x509.Certificate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i].Raw, b[i].Raw) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w *CertificateWatcher) onDelete(obj interface{}) {
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			secret, ok = tombstone.Obj.(*v1.Secret)
+			if !ok {
+				return
+			}
+		} else {
+			return
+		}
+	}
+
+	w.mu.Lock()
+	delete(w.certs, secretCacheKey(secret.Namespace, secret.Name))
+	w.mu.Unlock()
+}
+
+// GetCertificateList recomputes DaysLeft (and the warn/crit evaluation that
+// depends on it) from the cached, already-parsed certificates, without
+// touching the API server.
+func (w *CertificateWatcher) GetCertificateList() (certificateInfoList []*CertificateInfo, warnings []string, errors []string) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for _, cached := range w.certs {
+		var certs []*CertificateInfo
+		certs, warnings, errors = evaluateCertificateChain(cached.namespace, cached.secretName, cached.chain, cached.caCerts, w.appConfig, warnings, errors)
+		certificateInfoList = append(certificateInfoList, certs...)
+
+		if w.eventEmitter != nil {
+			secretRef := &v1.ObjectReference{
+				Kind:      "Secret",
+				Namespace: cached.namespace,
+				Name:      cached.secretName,
+				UID:       cached.uid,
+			}
+			w.eventEmitter.EmitForSecret(secretRef, certs[0], w.appConfig.CritDaysLeft, w.appConfig.WarnDaysLeft)
+		}
+	}
+
+	if warnings == nil {
+		warnings = make([]string, 0)
+	}
+
+	if errors == nil {
+		errors = make([]string, 0)
+	}
+
+	return certificateInfoList, warnings, errors
+}
+
+// ComputeTransitions diffs each leaf certificate's current warn/crit/expired
+// state against its state on the previous call, returning only the ones
+// that changed. Intermediates and CA certs are not tracked here since
+// notification sinks care about the cert end users actually hit.
+//
+// Only the primary secret scan participates in transition tracking: the
+// extra sources (ENABLE_INGRESS_SOURCE, ENABLE_CERTMANAGER_SOURCE, ...) can
+// emit multiple CertificateInfo entries for the same namespace/secretName
+// as the secret they describe, which would otherwise stomp the same
+// previousStates key and produce duplicate or contradictory events for one
+// real certificate. They remain metrics/inventory-only, the same way
+// check.go's scanCertificates never consults them.
+func (w *CertificateWatcher) ComputeTransitions(certList []*CertificateInfo) []notifier.CertificateEvent {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+
+	var events []notifier.CertificateEvent
+
+	for _, info := range certList {
+		if info.Kind != CertificateKindLeaf || info.Source != SourceSecret {
+			continue
+		}
+
+		state := certificateState(info, w.appConfig.CritDaysLeft, w.appConfig.WarnDaysLeft)
+		key := secretCacheKey(info.Namespace, info.SecretName)
+
+		previous, hadPrevious := w.previousStates[key]
+		w.previousStates[key] = state
+
+		if !hadPrevious || previous == state {
+			continue
+		}
+
+		commonName := ""
+		if len(info.CommonNames) > 0 {
+			commonName = info.CommonNames[0]
+		}
+
+		events = append(events, notifier.CertificateEvent{
+			Namespace:  info.Namespace,
+			SecretName: info.SecretName,
+			Kind:       string(info.Kind),
+			CommonName: commonName,
+			DaysLeft:   info.DaysLeft,
+			NotAfter:   info.NotAfter,
+			FromState:  previous,
+			ToState:    state,
+		})
+	}
+
+	return events
+}